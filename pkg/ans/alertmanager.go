@@ -0,0 +1,183 @@
+package ans
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/SAP/jenkins-library/pkg/body"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// AlertmanagerConfig configures polling of a Prometheus Alertmanager instance for firing alerts.
+type AlertmanagerConfig struct {
+	URL        string `json:"url,omitempty"`
+	APIVersion string `json:"apiVersion,omitempty"` // "v1" or "v2", defaults to "v2"
+}
+
+// alertmanagerAlert is the subset of the Alertmanager v1/v2 "GET /api/.../alerts" response used
+// to build an Event. Both API versions share this shape closely enough to decode into one struct.
+type alertmanagerAlert struct {
+	Labels      map[string]string `json:"labels"`
+	Annotations map[string]string `json:"annotations"`
+	StartsAt    time.Time         `json:"startsAt"`
+	Fingerprint string            `json:"fingerprint"`
+}
+
+// alertmanagerResponse wraps the v1 API's {"data": [...]} envelope; the v2 API returns the array
+// directly, so callers decode into whichever shape matches the configured APIVersion.
+type alertmanagerResponse struct {
+	Data []alertmanagerAlert `json:"data"`
+}
+
+// fetchAlerts retrieves the currently firing alerts from Alertmanager's HTTP API. The request
+// honors ctx so a caller polling on an interval (see Watch) can abort an in-flight request
+// instead of waiting for it to time out on its own.
+func fetchAlerts(ctx context.Context, config AlertmanagerConfig) ([]alertmanagerAlert, error) {
+	apiVersion := config.APIVersion
+	if apiVersion == "" {
+		apiVersion = "v2"
+	}
+
+	url := strings.TrimSuffix(config.URL, "/") + fmt.Sprintf("/api/%s/alerts", apiVersion)
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error building Alertmanager request to '%s'", url)
+	}
+
+	httpClient := http.Client{}
+	response, err := httpClient.Do(request)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error requesting alerts from Alertmanager at '%s'", url)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		responseBody, readErr := body.ReadResponseBody(response)
+		if readErr != nil {
+			return nil, fmt.Errorf("Alertmanager request to '%s' failed with status code %d", url, response.StatusCode)
+		}
+		return nil, fmt.Errorf("Alertmanager request to '%s' failed with status code %d; response body: %s", url, response.StatusCode, responseBody)
+	}
+
+	if apiVersion == "v1" {
+		var wrapped alertmanagerResponse
+		if err := json.NewDecoder(response.Body).Decode(&wrapped); err != nil {
+			return nil, errors.Wrap(err, "error decoding Alertmanager v1 alerts response")
+		}
+		return wrapped.Data, nil
+	}
+
+	var alerts []alertmanagerAlert
+	if err := json.NewDecoder(response.Body).Decode(&alerts); err != nil {
+		return nil, errors.Wrap(err, "error decoding Alertmanager v2 alerts response")
+	}
+	return alerts, nil
+}
+
+// toEvent maps an Alertmanager alert's labels/annotations onto an ANS Event, translating the
+// "severity" label to the ANS severity constants and using "alertname" as the EventType.
+func (alert alertmanagerAlert) toEvent() Event {
+	event := Event{
+		EventType:      alert.Labels["alertname"],
+		EventTimestamp: alert.StartsAt.Unix(),
+		Severity:       alertmanagerSeverity(alert.Labels["severity"]),
+		Category:       alertCategory,
+		Subject:        alert.Annotations["summary"],
+		Body:           alert.Annotations["description"],
+		Tags:           map[string]interface{}{},
+	}
+	for key, value := range alert.Labels {
+		event.Tags[key] = value
+	}
+	return event
+}
+
+func alertmanagerSeverity(severity string) string {
+	switch strings.ToLower(severity) {
+	case "critical":
+		return fatalSeverity
+	case "warning":
+		return warningSeverity
+	case "info", "none":
+		return infoSeverity
+	default:
+		return errorSeverity
+	}
+}
+
+// IngestAlerts fetches the currently firing Alertmanager alerts once, converts each one to an
+// Event and forwards it via client.Send. It returns the events that were sent, for CI step
+// reporting/logging. A failure sending one event does not prevent the others from being sent;
+// the first Send error encountered is returned alongside whatever succeeded.
+func IngestAlerts(config AlertmanagerConfig, client Client) ([]Event, error) {
+	alerts, err := fetchAlerts(context.Background(), config)
+	if err != nil {
+		return nil, err
+	}
+
+	var sent []Event
+	var sendErr error
+	for _, alert := range alerts {
+		event := alert.toEvent()
+		if err := client.Send(event); err != nil {
+			if sendErr == nil {
+				sendErr = errors.Wrapf(err, "error sending event for alert '%s'", alert.Fingerprint)
+			}
+			continue
+		}
+		sent = append(sent, event)
+	}
+	return sent, sendErr
+}
+
+// Watch polls Alertmanager at the given interval until ctx is cancelled, forwarding every newly
+// firing alert to client.Send. Alerts are deduped by fingerprint so a still-firing alert is only
+// sent once; once an alert stops being reported by Alertmanager it is forgotten and will be
+// re-sent if it fires again later. A failed poll (network blip, a bad decode, a Send error for
+// one alert) is logged and does not stop the watch; Watch only returns once ctx is done.
+func Watch(ctx context.Context, config AlertmanagerConfig, client Client, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	seen := map[string]bool{}
+
+	poll := func() {
+		alerts, err := fetchAlerts(ctx, config)
+		if err != nil {
+			logrus.WithError(err).Error("error polling Alertmanager for alerts, will retry on the next tick")
+			return
+		}
+
+		active := map[string]bool{}
+		for _, alert := range alerts {
+			active[alert.Fingerprint] = true
+			if seen[alert.Fingerprint] {
+				continue
+			}
+			if err := client.Send(alert.toEvent()); err != nil {
+				logrus.WithError(err).Errorf("error sending event for alert '%s', will retry on the next tick", alert.Fingerprint)
+				continue
+			}
+			seen[alert.Fingerprint] = true
+		}
+		for fingerprint := range seen {
+			if !active[fingerprint] {
+				delete(seen, fingerprint)
+			}
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			poll()
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package ans
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func alertmanagerServer(t *testing.T, apiVersion string, alerts func() []alertmanagerAlert) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/"+apiVersion+"/alerts", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if apiVersion == "v1" {
+			assert.NoError(t, json.NewEncoder(w).Encode(alertmanagerResponse{Data: alerts()}))
+			return
+		}
+		assert.NoError(t, json.NewEncoder(w).Encode(alerts()))
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestFetchAlertsDecodesV2Response(t *testing.T) {
+	server := alertmanagerServer(t, "v2", func() []alertmanagerAlert {
+		return []alertmanagerAlert{{Fingerprint: "abc", Labels: map[string]string{"alertname": "HighCPU"}}}
+	})
+
+	alerts, err := fetchAlerts(context.Background(), AlertmanagerConfig{URL: server.URL})
+	assert.NoError(t, err)
+	assert.Len(t, alerts, 1)
+	assert.Equal(t, "abc", alerts[0].Fingerprint)
+}
+
+func TestFetchAlertsDecodesV1Response(t *testing.T) {
+	server := alertmanagerServer(t, "v1", func() []alertmanagerAlert {
+		return []alertmanagerAlert{{Fingerprint: "def", Labels: map[string]string{"alertname": "DiskFull"}}}
+	})
+
+	alerts, err := fetchAlerts(context.Background(), AlertmanagerConfig{URL: server.URL, APIVersion: "v1"})
+	assert.NoError(t, err)
+	assert.Len(t, alerts, 1)
+	assert.Equal(t, "def", alerts[0].Fingerprint)
+}
+
+func TestIngestAlertsSendsEachAlertOnce(t *testing.T) {
+	server := alertmanagerServer(t, "v2", func() []alertmanagerAlert {
+		return []alertmanagerAlert{
+			{Fingerprint: "a", Labels: map[string]string{"alertname": "A", "severity": "critical"}},
+			{Fingerprint: "b", Labels: map[string]string{"alertname": "B", "severity": "warning"}},
+		}
+	})
+
+	client := &fakeClient{}
+	sent, err := IngestAlerts(AlertmanagerConfig{URL: server.URL}, client)
+	assert.NoError(t, err)
+	assert.Len(t, sent, 2)
+	assert.Equal(t, 2, client.count())
+}
+
+func TestWatchDedupesAlertAcrossPolls(t *testing.T) {
+	server := alertmanagerServer(t, "v2", func() []alertmanagerAlert {
+		return []alertmanagerAlert{{Fingerprint: "steady", Labels: map[string]string{"alertname": "StillFiring"}}}
+	})
+
+	client := &fakeClient{}
+	ctx, cancel := context.WithTimeout(context.Background(), 45*time.Millisecond)
+	defer cancel()
+	err := Watch(ctx, AlertmanagerConfig{URL: server.URL}, client, 5*time.Millisecond)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, client.count(), "a still-firing alert should only be sent once across many polls")
+}
+
+func TestWatchResendsAlertAfterItClearsAndRefires(t *testing.T) {
+	var poll int32
+	server := alertmanagerServer(t, "v2", func() []alertmanagerAlert {
+		n := atomic.AddInt32(&poll, 1)
+		// fire on the first poll, clear on the second, fire again from the third poll onward
+		if n == 2 {
+			return nil
+		}
+		return []alertmanagerAlert{{Fingerprint: "flapping", Labels: map[string]string{"alertname": "Flapping"}}}
+	})
+
+	client := &fakeClient{}
+	ctx, cancel := context.WithTimeout(context.Background(), 45*time.Millisecond)
+	defer cancel()
+	err := Watch(ctx, AlertmanagerConfig{URL: server.URL}, client, 5*time.Millisecond)
+
+	assert.NoError(t, err)
+	assert.True(t, client.count() >= 2, "expected the alert to be re-sent after it cleared and fired again")
+}
+
+func TestWatchStopsWhenContextIsDone(t *testing.T) {
+	server := alertmanagerServer(t, "v2", func() []alertmanagerAlert { return nil })
+
+	client := &fakeClient{}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := Watch(ctx, AlertmanagerConfig{URL: server.URL}, client, time.Hour)
+	assert.NoError(t, err)
+}
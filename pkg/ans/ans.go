@@ -2,13 +2,18 @@ package ans
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/SAP/jenkins-library/pkg/body"
 	"github.com/SAP/jenkins-library/pkg/xsuaa"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
+	"math"
+	"math/rand"
 	"net/http"
+	"strconv"
+	"time"
 )
 
 const (
@@ -30,6 +35,45 @@ const (
 type ANS struct {
 	XSUAA xsuaa.XSUAA
 	URL   string
+
+	// MaxRetries is the number of retries attempted after a failed send before giving up, zero
+	// disables retrying. InitialBackoff/MaxBackoff bound the exponential backoff between
+	// attempts; a Retry-After header on a 429/503 response overrides the computed backoff.
+	MaxRetries     int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+
+	// CircuitBreaker, when set, is consulted before every send attempt and short-circuits
+	// SendContext once CircuitBreakerThreshold consecutive failures have been observed. It is a
+	// pointer so that copies of ANS share the same breaker state.
+	CircuitBreaker *CircuitBreaker
+
+	// Format selects the wire format Send/SendContext use, see the Format* constants. Defaults
+	// to FormatANS; use SendCloudEvent(Context) to send a single event as CloudEvents regardless
+	// of this field.
+	Format string
+	// CloudEventSource is the CloudEvents "source" attribute to use when Format is
+	// FormatCloudEvents. It defaults to URL when empty.
+	CloudEventSource string
+}
+
+// NewANS builds an ANS client for the given xsuaa setup and backend URL, wiring Configuration's
+// MaxRetries/InitialBackoff/MaxBackoff/CircuitBreakerThreshold/Format into the runtime retry
+// policy ANS.SendContext reads. A zero CircuitBreakerThreshold leaves the circuit breaker unset.
+func NewANS(xsuaaConfig xsuaa.XSUAA, url string, config Configuration) ANS {
+	ans := ANS{
+		XSUAA:            xsuaaConfig,
+		URL:              url,
+		MaxRetries:       config.MaxRetries,
+		InitialBackoff:   config.InitialBackoff,
+		MaxBackoff:       config.MaxBackoff,
+		Format:           config.Format,
+		CloudEventSource: config.CloudEventSource,
+	}
+	if config.CircuitBreakerThreshold > 0 {
+		ans.CircuitBreaker = NewCircuitBreaker(config.CircuitBreakerThreshold)
+	}
+	return ans
 }
 
 // Configuration defines the configuration options for the SAP Alert Notification Service on BTP
@@ -37,6 +81,27 @@ type Configuration struct {
 	ServiceKey            string `json:"serviceKey,omitempty"`
 	EventTemplateFilePath string `json:"eventTemplateFilePath,omitempty"`
 	EventTemplate         string `json:"eventTemplate,omitempty"`
+
+	// Transport selects where events are sent, see the Transport* constants. Defaults to ANS.
+	Transport string         `json:"transport,omitempty"`
+	NATS      NATSConfig     `json:"nats,omitempty"`
+	MSTeams   MSTeamsConfig  `json:"msTeams,omitempty"`
+	Opsgenie  OpsgenieConfig `json:"opsgenie,omitempty"`
+	Webhook   WebhookConfig  `json:"webhook,omitempty"`
+
+	// MaxRetries, InitialBackoff, MaxBackoff and CircuitBreakerThreshold configure ANS.Send's
+	// retry behavior, see ANS.MaxRetries/CircuitBreaker for the runtime policy they map to.
+	MaxRetries              int           `json:"maxRetries,omitempty"`
+	InitialBackoff          time.Duration `json:"initialBackoff,omitempty"`
+	MaxBackoff              time.Duration `json:"maxBackoff,omitempty"`
+	CircuitBreakerThreshold int           `json:"circuitBreakerThreshold,omitempty"`
+
+	// Format selects the event wire format, see the Format* constants. Defaults to FormatANS.
+	Format string `json:"format,omitempty"`
+
+	// CloudEventSource sets ANS.CloudEventSource, the "source" attribute used when Format is
+	// FormatCloudEvents. Defaults to the ANS URL when unset.
+	CloudEventSource string `json:"cloudEventSource,omitempty"`
 }
 
 // Client to send the event to the SAP Alert Notification Service
@@ -93,11 +158,29 @@ func (event *Event) MergeWithJSON(eventJSON []byte) (err error) {
 	return
 }
 
-// Send an event to the SAP Alert Notification Service
-func (ans ANS) Send(event Event) error {
+// retryableStatusError is returned by sendOnce for a failure that is worth retrying: a
+// transport-level error or a 429/5xx response status. Its retryAfter, if non-zero, reflects a
+// Retry-After header the server asked us to honor.
+type retryableStatusError struct {
+	err        error
+	retryAfter time.Duration
+}
+
+func (e *retryableStatusError) Error() string { return e.err.Error() }
+
+// sendOnce performs a single POST of event to the ANS backend, with no retrying. The wire format
+// is plain ANS JSON unless ans.Format selects FormatCloudEvents.
+func (ans ANS) sendOnce(ctx context.Context, event Event) error {
 	const eventPath = "/cf/producer/v1/resource-events"
 
-	requestBody, err := json.Marshal(event)
+	contentType := "application/json"
+	var payload interface{} = event
+	if ans.Format == FormatCloudEvents {
+		contentType = "application/cloudevents+json"
+		payload = newCloudEvent(event, ans.cloudEventSource())
+	}
+
+	requestBody, err := json.Marshal(payload)
 	if err != nil {
 		return err
 	}
@@ -111,16 +194,20 @@ func (ans ANS) Send(event Event) error {
 	entireUrl := ans.URL + eventPath
 
 	httpClient := http.Client{}
-	request, err := http.NewRequest(http.MethodPost, entireUrl, bytes.NewBuffer(requestBody))
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, entireUrl, bytes.NewBuffer(requestBody))
 	if err != nil {
 		return err
 	}
 	request.Header.Add(authHeaderKey, header.Get(authHeaderKey))
-	request.Header.Add("Content-Type", "application/json")
+	request.Header.Add("Content-Type", contentType)
 	response, err := httpClient.Do(request)
 	if err != nil {
-		return err
+		// transport-level failures (timeouts, connection refused, DNS errors, ...) are just as
+		// transient as a 429/503 from the backend, so they're retried the same way.
+		return &retryableStatusError{err: err}
 	}
+	defer response.Body.Close()
+
 	if response.StatusCode != http.StatusAccepted {
 		statusCodeError := fmt.Errorf("ANS http request to '%s' failed. Did not get expected status code %d; instead got %d",
 			entireUrl, http.StatusAccepted, response.StatusCode)
@@ -130,12 +217,115 @@ func (ans ANS) Send(event Event) error {
 		} else {
 			err = fmt.Errorf("%s; response body: %s", statusCodeError.Error(), responseBody)
 		}
+
+		if response.StatusCode == http.StatusTooManyRequests || response.StatusCode >= http.StatusInternalServerError {
+			return &retryableStatusError{err: err, retryAfter: retryAfterDuration(response.Header.Get("Retry-After"))}
+		}
 		return err
 	}
 
 	return nil
 }
 
+// retryAfterDuration parses a Retry-After header value given in seconds; it returns zero if the
+// header is absent or not a plain integer (HTTP-date Retry-After values are not supported).
+func retryAfterDuration(header string) time.Duration {
+	if header == "" {
+		return 0
+	}
+	seconds, err := strconv.Atoi(header)
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// Send an event to the SAP Alert Notification Service. It is equivalent to calling SendContext
+// with context.Background().
+func (ans ANS) Send(event Event) error {
+	return ans.SendContext(context.Background(), event)
+}
+
+// SendContext sends an event to the SAP Alert Notification Service, retrying transient failures
+// (transport errors and 429/5xx responses) with exponential backoff and jitter up to MaxRetries
+// times, honoring a Retry-After header when the backend sends one. If a CircuitBreaker is set and
+// open, SendContext fails fast without attempting the request. ctx can be used to abort a long
+// retry loop, e.g. when a pipeline step is being cancelled.
+func (ans ANS) SendContext(ctx context.Context, event Event) error {
+	return ans.sendWithRetry(ctx, func(ctx context.Context) error {
+		return ans.sendOnce(ctx, event)
+	})
+}
+
+// sendWithRetry runs send, retrying on a *retryableStatusError with exponential backoff and
+// jitter up to ans.MaxRetries times. It is split out from SendContext so the retry/backoff/
+// circuit-breaker state machine can be tested without a real HTTP backend.
+func (ans ANS) sendWithRetry(ctx context.Context, send func(context.Context) error) error {
+	if ans.CircuitBreaker != nil && !ans.CircuitBreaker.allow() {
+		return fmt.Errorf("ANS circuit breaker is open, not sending event to '%s'", ans.URL)
+	}
+
+	backoff := ans.InitialBackoff
+	if backoff <= 0 {
+		backoff = 500 * time.Millisecond
+	}
+	maxBackoff := ans.MaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = 30 * time.Second
+	}
+
+	var err error
+	for attempt := 0; attempt <= ans.MaxRetries; attempt++ {
+		err = send(ctx)
+		if err == nil {
+			if ans.CircuitBreaker != nil {
+				ans.CircuitBreaker.recordSuccess()
+			}
+			return nil
+		}
+
+		if attempt == ans.MaxRetries {
+			break
+		}
+
+		retryable, ok := err.(*retryableStatusError)
+		if !ok {
+			break
+		}
+
+		wait := backoffDuration(retryable.retryAfter, backoff, maxBackoff, attempt)
+
+		select {
+		case <-ctx.Done():
+			err = ctx.Err()
+			attempt = ans.MaxRetries // stop retrying, report ctx error below
+		case <-time.After(wait):
+		}
+	}
+
+	if ans.CircuitBreaker != nil {
+		ans.CircuitBreaker.recordFailure()
+	}
+	if retryable, ok := err.(*retryableStatusError); ok {
+		return retryable.err
+	}
+	return err
+}
+
+// backoffDuration returns how long to wait before the next retry: retryAfter if the backend gave
+// one, otherwise an exponential backoff (initial * 2^attempt, capped at maxBackoff) plus up to 50%
+// jitter so that many callers retrying at once don't all hammer the backend in lockstep.
+func backoffDuration(retryAfter, initial, maxBackoff time.Duration, attempt int) time.Duration {
+	if retryAfter > 0 {
+		return retryAfter
+	}
+	wait := time.Duration(float64(initial) * math.Pow(2, float64(attempt)))
+	if wait > maxBackoff {
+		wait = maxBackoff
+	}
+	return wait + time.Duration(rand.Int63n(int64(wait)/2+1))
+}
+
 // TranslateLogrusLogLevel takes the logrus log level and translates it to an ANS severity ans category string
 func TranslateLogrusLogLevel(level logrus.Level) (severity, category string) {
 	severity = infoSeverity
@@ -0,0 +1,113 @@
+package ans
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSendWithRetrySucceedsAfterTransientFailures(t *testing.T) {
+	ans := ANS{MaxRetries: 3, InitialBackoff: time.Millisecond, MaxBackoff: 5 * time.Millisecond}
+
+	attempts := 0
+	err := ans.sendWithRetry(context.Background(), func(ctx context.Context) error {
+		attempts++
+		if attempts < 3 {
+			return &retryableStatusError{err: errors.New("503 service unavailable")}
+		}
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, attempts)
+}
+
+func TestSendWithRetryGivesUpAfterMaxRetries(t *testing.T) {
+	ans := ANS{MaxRetries: 2, InitialBackoff: time.Millisecond, MaxBackoff: 5 * time.Millisecond}
+
+	attempts := 0
+	err := ans.sendWithRetry(context.Background(), func(ctx context.Context) error {
+		attempts++
+		return &retryableStatusError{err: errors.New("still failing")}
+	})
+
+	assert.Error(t, err)
+	assert.Equal(t, 3, attempts) // the initial attempt plus MaxRetries retries
+}
+
+func TestSendWithRetryDoesNotRetryNonRetryableError(t *testing.T) {
+	ans := ANS{MaxRetries: 5, InitialBackoff: time.Millisecond, MaxBackoff: 5 * time.Millisecond}
+
+	attempts := 0
+	err := ans.sendWithRetry(context.Background(), func(ctx context.Context) error {
+		attempts++
+		return errors.New("400 bad request")
+	})
+
+	assert.Error(t, err)
+	assert.Equal(t, 1, attempts)
+}
+
+func TestSendWithRetryStopsWhenContextIsDone(t *testing.T) {
+	ans := ANS{MaxRetries: 10, InitialBackoff: 50 * time.Millisecond, MaxBackoff: time.Second}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	attempts := 0
+	err := ans.sendWithRetry(ctx, func(ctx context.Context) error {
+		attempts++
+		if attempts == 1 {
+			cancel()
+		}
+		return &retryableStatusError{err: errors.New("503 service unavailable")}
+	})
+
+	assert.Error(t, err)
+	assert.Equal(t, 1, attempts)
+}
+
+func TestSendWithRetryOpenCircuitBreakerFailsFast(t *testing.T) {
+	breaker := NewCircuitBreaker(1)
+	breaker.recordFailure()
+	ans := ANS{CircuitBreaker: breaker}
+
+	attempts := 0
+	err := ans.sendWithRetry(context.Background(), func(ctx context.Context) error {
+		attempts++
+		return nil
+	})
+
+	assert.Error(t, err)
+	assert.Equal(t, 0, attempts)
+}
+
+func TestSendWithRetryRecordsCircuitBreakerOutcome(t *testing.T) {
+	breaker := NewCircuitBreaker(2)
+	ans := ANS{MaxRetries: 0, CircuitBreaker: breaker}
+
+	err := ans.sendWithRetry(context.Background(), func(ctx context.Context) error {
+		return errors.New("non-retryable")
+	})
+	assert.Error(t, err)
+	assert.True(t, breaker.allow(), "breaker should still be closed after a single failure below the threshold")
+
+	err = ans.sendWithRetry(context.Background(), func(ctx context.Context) error {
+		return errors.New("non-retryable")
+	})
+	assert.Error(t, err)
+	assert.False(t, breaker.allow(), "breaker should open once the threshold of consecutive failures is reached")
+}
+
+func TestBackoffDurationHonorsRetryAfter(t *testing.T) {
+	wait := backoffDuration(7*time.Second, time.Millisecond, time.Second, 0)
+	assert.Equal(t, 7*time.Second, wait)
+}
+
+func TestBackoffDurationCapsAtMaxBackoff(t *testing.T) {
+	for attempt := 0; attempt < 10; attempt++ {
+		wait := backoffDuration(0, time.Millisecond, 10*time.Millisecond, attempt)
+		assert.True(t, wait <= 15*time.Millisecond, "expected backoff to stay within max backoff plus jitter")
+	}
+}
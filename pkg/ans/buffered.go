@@ -0,0 +1,217 @@
+package ans
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// OverflowPolicy controls what BufferedClient.Enqueue does when the in-memory queue is full.
+type OverflowPolicy int
+
+const (
+	// DropOldest discards the oldest queued event to make room for the new one.
+	DropOldest OverflowPolicy = iota
+	// BlockOnFull makes Enqueue block until space frees up or the context passed to
+	// EnqueueContext is done.
+	BlockOnFull
+)
+
+// BufferedClientConfig configures a BufferedClient's batching and overflow behavior.
+type BufferedClientConfig struct {
+	// FlushInterval is the longest an event waits in the queue before being sent.
+	FlushInterval time.Duration
+	// MaxBatchSize triggers an immediate flush once this many events are queued.
+	MaxBatchSize int
+	// MaxQueueSize bounds how many events may be queued at once; OverflowPolicy decides what
+	// happens when Enqueue is called while the queue is already at this size.
+	MaxQueueSize int
+	// OverflowPolicy selects the behavior when the queue is full, see the OverflowPolicy values.
+	OverflowPolicy OverflowPolicy
+}
+
+func (config BufferedClientConfig) withDefaults() BufferedClientConfig {
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = 5 * time.Second
+	}
+	if config.MaxBatchSize <= 0 {
+		config.MaxBatchSize = 20
+	}
+	if config.MaxQueueSize <= 0 {
+		config.MaxQueueSize = 10 * config.MaxBatchSize
+	}
+	return config
+}
+
+type flushRequest struct {
+	done chan struct{}
+}
+
+// BufferedClient wraps a Client so that high-volume callers, e.g. a logrus hook emitting one
+// event per log line, don't block on network latency to the underlying transport. Events are
+// queued in memory and sent from a single background goroutine, either once MaxBatchSize events
+// have accumulated or on the next tick of a fixed FlushInterval ticker, whichever comes first. A
+// flush triggered by MaxBatchSize does not reset the ticker.
+type BufferedClient struct {
+	client Client
+	config BufferedClientConfig
+
+	mu    sync.Mutex
+	cond  *sync.Cond
+	queue []Event
+
+	countersMu sync.Mutex
+	counters   map[string]int64
+
+	flushReq chan flushRequest
+	stop     chan struct{}
+	stopped  chan struct{}
+}
+
+// NewBufferedClient starts a BufferedClient wrapping client with the given configuration. Callers
+// must call Close when done to stop the background goroutine and flush any remaining events.
+func NewBufferedClient(client Client, config BufferedClientConfig) *BufferedClient {
+	bc := &BufferedClient{
+		client:   client,
+		config:   config.withDefaults(),
+		counters: map[string]int64{},
+		// Buffered by 1 so that a MaxBatchSize-triggered flush signal is never silently dropped
+		// just because run() hasn't returned to its select yet (e.g. it's mid-flush): the signal
+		// queues up and is picked up as soon as run() is ready, instead of waiting for the next
+		// FlushInterval tick.
+		flushReq: make(chan flushRequest, 1),
+		stop:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+	bc.cond = sync.NewCond(&bc.mu)
+	go bc.run()
+	return bc
+}
+
+// Enqueue queues event for asynchronous delivery. Under DropOldest (the default) it never blocks;
+// under BlockOnFull it blocks until the queue has room. Use EnqueueContext to bound that wait.
+func (bc *BufferedClient) Enqueue(event Event) error {
+	return bc.EnqueueContext(context.Background(), event)
+}
+
+// EnqueueContext is like Enqueue but aborts a BlockOnFull wait when ctx is done.
+func (bc *BufferedClient) EnqueueContext(ctx context.Context, event Event) error {
+	// sync.Cond.Wait only re-checks ctx on the next wakeup, and nothing but a flush otherwise
+	// wakes it; without this, a cancelled/expired ctx would never be noticed while the queue
+	// stays full. Wake every waiter once ctx is done so they can re-check and return.
+	if done := ctx.Done(); done != nil {
+		stopWatch := make(chan struct{})
+		defer close(stopWatch)
+		go func() {
+			select {
+			case <-done:
+				bc.mu.Lock()
+				bc.cond.Broadcast()
+				bc.mu.Unlock()
+			case <-stopWatch:
+			}
+		}()
+	}
+
+	bc.mu.Lock()
+	for len(bc.queue) >= bc.config.MaxQueueSize {
+		switch bc.config.OverflowPolicy {
+		case BlockOnFull:
+			if err := ctx.Err(); err != nil {
+				bc.mu.Unlock()
+				return err
+			}
+			bc.cond.Wait()
+		default: // DropOldest
+			bc.queue = bc.queue[1:]
+		}
+	}
+	bc.queue = append(bc.queue, event)
+	full := len(bc.queue) >= bc.config.MaxBatchSize
+	bc.mu.Unlock()
+
+	if full {
+		select {
+		case bc.flushReq <- flushRequest{}:
+		default:
+			// a flush is already queued to run and will pick up everything enqueued so far
+		}
+	}
+	return nil
+}
+
+// Flush blocks until every event queued so far has been sent, or ctx is done.
+func (bc *BufferedClient) Flush(ctx context.Context) error {
+	done := make(chan struct{})
+	select {
+	case bc.flushReq <- flushRequest{done: done}:
+	case <-bc.stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close flushes any remaining events and stops the background goroutine. It is safe to call
+// Close without ever calling Flush.
+func (bc *BufferedClient) Close() error {
+	close(bc.stop)
+	<-bc.stopped
+	return nil
+}
+
+// Counters returns how many events have been sent so far, keyed by Event.Severity.
+func (bc *BufferedClient) Counters() map[string]int64 {
+	bc.countersMu.Lock()
+	defer bc.countersMu.Unlock()
+	counters := make(map[string]int64, len(bc.counters))
+	for severity, count := range bc.counters {
+		counters[severity] = count
+	}
+	return counters
+}
+
+func (bc *BufferedClient) run() {
+	ticker := time.NewTicker(bc.config.FlushInterval)
+	defer ticker.Stop()
+	defer close(bc.stopped)
+
+	for {
+		select {
+		case <-bc.stop:
+			bc.flush()
+			return
+		case req := <-bc.flushReq:
+			bc.flush()
+			if req.done != nil {
+				close(req.done)
+			}
+		case <-ticker.C:
+			bc.flush()
+		}
+	}
+}
+
+// flush sends every event currently queued, one Send call per event since Client has no batch
+// endpoint; it keeps going on a per-event Send error so one bad event doesn't block the rest.
+func (bc *BufferedClient) flush() {
+	bc.mu.Lock()
+	batch := bc.queue
+	bc.queue = nil
+	bc.cond.Broadcast()
+	bc.mu.Unlock()
+
+	for _, event := range batch {
+		if err := bc.client.Send(event); err == nil {
+			bc.countersMu.Lock()
+			bc.counters[event.Severity]++
+			bc.countersMu.Unlock()
+		}
+	}
+}
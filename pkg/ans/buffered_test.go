@@ -0,0 +1,150 @@
+package ans
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeClient struct {
+	mu   sync.Mutex
+	sent []Event
+}
+
+func (f *fakeClient) Send(event Event) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.sent = append(f.sent, event)
+	return nil
+}
+
+func (f *fakeClient) count() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.sent)
+}
+
+func TestBufferedClientFlushDeliversQueuedEvents(t *testing.T) {
+	client := &fakeClient{}
+	bc := NewBufferedClient(client, BufferedClientConfig{FlushInterval: time.Hour, MaxBatchSize: 100})
+	defer bc.Close()
+
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, bc.Enqueue(Event{EventType: "evt", Severity: infoSeverity}))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.NoError(t, bc.Flush(ctx))
+
+	assert.Equal(t, 5, client.count())
+	assert.Equal(t, int64(5), bc.Counters()[infoSeverity])
+}
+
+func TestBufferedClientFlushesImmediatelyOnMaxBatchSize(t *testing.T) {
+	client := &fakeClient{}
+	bc := NewBufferedClient(client, BufferedClientConfig{FlushInterval: time.Hour, MaxBatchSize: 3})
+	defer bc.Close()
+
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, bc.Enqueue(Event{EventType: "evt"}))
+	}
+
+	assert.Eventually(t, func() bool { return client.count() == 3 }, time.Second, time.Millisecond)
+}
+
+func TestBufferedClientFlushesOnInterval(t *testing.T) {
+	client := &fakeClient{}
+	bc := NewBufferedClient(client, BufferedClientConfig{FlushInterval: 10 * time.Millisecond, MaxBatchSize: 100})
+	defer bc.Close()
+
+	assert.NoError(t, bc.Enqueue(Event{EventType: "evt"}))
+	assert.Eventually(t, func() bool { return client.count() == 1 }, time.Second, 5*time.Millisecond)
+}
+
+func TestBufferedClientDropOldestDiscardsOldestEventWhenFull(t *testing.T) {
+	client := &fakeClient{}
+	bc := NewBufferedClient(client, BufferedClientConfig{
+		FlushInterval:  time.Hour,
+		MaxBatchSize:   100,
+		MaxQueueSize:   2,
+		OverflowPolicy: DropOldest,
+	})
+	defer bc.Close()
+
+	assert.NoError(t, bc.Enqueue(Event{EventType: "first"}))
+	assert.NoError(t, bc.Enqueue(Event{EventType: "second"}))
+	assert.NoError(t, bc.Enqueue(Event{EventType: "third"}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.NoError(t, bc.Flush(ctx))
+
+	assert.Equal(t, 2, client.count())
+	assert.Equal(t, "second", client.sent[0].EventType)
+	assert.Equal(t, "third", client.sent[1].EventType)
+}
+
+func TestBufferedClientBlockOnFullUnblocksAfterFlush(t *testing.T) {
+	client := &fakeClient{}
+	bc := NewBufferedClient(client, BufferedClientConfig{
+		FlushInterval:  time.Hour,
+		MaxBatchSize:   100,
+		MaxQueueSize:   1,
+		OverflowPolicy: BlockOnFull,
+	})
+	defer bc.Close()
+
+	assert.NoError(t, bc.Enqueue(Event{EventType: "first"}))
+
+	done := make(chan error, 1)
+	go func() { done <- bc.Enqueue(Event{EventType: "second"}) }()
+
+	select {
+	case <-done:
+		t.Fatal("expected Enqueue to block while the queue is full")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.NoError(t, bc.Flush(ctx))
+
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("expected the blocked Enqueue to return after flush freed up space")
+	}
+}
+
+func TestBufferedClientEnqueueContextAbortsBlockOnFullWait(t *testing.T) {
+	client := &fakeClient{}
+	bc := NewBufferedClient(client, BufferedClientConfig{
+		FlushInterval:  time.Hour,
+		MaxBatchSize:   100,
+		MaxQueueSize:   1,
+		OverflowPolicy: BlockOnFull,
+	})
+	defer bc.Close()
+
+	assert.NoError(t, bc.Enqueue(Event{EventType: "first"}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := bc.EnqueueContext(ctx, Event{EventType: "second"})
+	assert.Error(t, err)
+}
+
+func TestBufferedClientCloseFlushesRemainingEvents(t *testing.T) {
+	client := &fakeClient{}
+	bc := NewBufferedClient(client, BufferedClientConfig{FlushInterval: time.Hour, MaxBatchSize: 100})
+
+	assert.NoError(t, bc.Enqueue(Event{EventType: "evt"}))
+	assert.NoError(t, bc.Close())
+
+	assert.Equal(t, 1, client.count())
+}
@@ -0,0 +1,73 @@
+package ans
+
+import (
+	"sync"
+	"time"
+)
+
+// defaultCircuitBreakerCooldown is how long a breaker opened by NewCircuitBreaker stays open
+// before letting a single probe request through again.
+const defaultCircuitBreakerCooldown = 30 * time.Second
+
+// CircuitBreaker is a minimal consecutive-failure circuit breaker used by ANS.SendContext to stop
+// hammering a backend that is already failing. It has two states: closed (requests are allowed)
+// and open (requests are rejected immediately). Once open, it lets a single probe request through
+// after cooldown has elapsed; a successful probe closes the breaker, a failed one reopens it.
+type CircuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu        sync.Mutex
+	failures  int
+	open      bool
+	openSince time.Time
+}
+
+// NewCircuitBreaker returns a CircuitBreaker that opens after threshold consecutive failures and
+// probes again after defaultCircuitBreakerCooldown. A non-positive threshold disables the
+// breaker; allow always returns true in that case.
+func NewCircuitBreaker(threshold int) *CircuitBreaker {
+	return &CircuitBreaker{threshold: threshold, cooldown: defaultCircuitBreakerCooldown}
+}
+
+// allow reports whether a request may proceed. It returns true if the breaker is closed, or if it
+// is open but cooldown has elapsed since it tripped, in which case a single probe is let through.
+func (cb *CircuitBreaker) allow() bool {
+	if cb.threshold <= 0 {
+		return true
+	}
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	if !cb.open {
+		return true
+	}
+	if time.Since(cb.openSince) < cb.cooldown {
+		return false
+	}
+	// restart the cooldown so that only this probe gets through until its outcome is recorded
+	cb.openSince = time.Now()
+	return true
+}
+
+// recordSuccess closes the breaker and resets the consecutive failure count.
+func (cb *CircuitBreaker) recordSuccess() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	cb.failures = 0
+	cb.open = false
+}
+
+// recordFailure increments the consecutive failure count, opening (or re-opening, after a failed
+// probe) the breaker once threshold is reached.
+func (cb *CircuitBreaker) recordFailure() {
+	if cb.threshold <= 0 {
+		return
+	}
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	cb.failures++
+	if cb.failures >= cb.threshold {
+		cb.open = true
+		cb.openSince = time.Now()
+	}
+}
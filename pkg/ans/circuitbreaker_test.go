@@ -0,0 +1,46 @@
+package ans
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCircuitBreakerOpensAfterThreshold(t *testing.T) {
+	breaker := NewCircuitBreaker(3)
+
+	assert.True(t, breaker.allow())
+	breaker.recordFailure()
+	breaker.recordFailure()
+	assert.True(t, breaker.allow(), "should still be closed below the threshold")
+
+	breaker.recordFailure()
+	assert.False(t, breaker.allow(), "should open once the threshold is reached")
+}
+
+func TestCircuitBreakerSuccessResetsFailureCount(t *testing.T) {
+	breaker := NewCircuitBreaker(2)
+
+	breaker.recordFailure()
+	breaker.recordSuccess()
+	breaker.recordFailure()
+	assert.True(t, breaker.allow(), "a success should reset the consecutive failure count")
+}
+
+func TestCircuitBreakerProbesAfterCooldown(t *testing.T) {
+	breaker := NewCircuitBreaker(1)
+	breaker.cooldown = time.Millisecond
+	breaker.recordFailure()
+	assert.False(t, breaker.allow())
+
+	time.Sleep(5 * time.Millisecond)
+	assert.True(t, breaker.allow(), "should let a probe through once cooldown has elapsed")
+}
+
+func TestCircuitBreakerDisabledWithNonPositiveThreshold(t *testing.T) {
+	breaker := NewCircuitBreaker(0)
+	breaker.recordFailure()
+	breaker.recordFailure()
+	assert.True(t, breaker.allow(), "a non-positive threshold should disable the breaker")
+}
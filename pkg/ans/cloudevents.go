@@ -0,0 +1,85 @@
+package ans
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"time"
+)
+
+// Format selectors for ANS.Format / Configuration.Format.
+const (
+	FormatANS         = "ans"
+	FormatCloudEvents = "cloudevents"
+)
+
+const cloudEventsSpecVersion = "1.0"
+
+// cloudEvent is a CNCF CloudEvents 1.0 structured-mode JSON envelope with event mapped into the
+// "data" attribute, so an ANS Event can be consumed by generic event brokers that speak
+// CloudEvents instead of only the SAP-specific schema.
+type cloudEvent struct {
+	SpecVersion     string `json:"specversion"`
+	ID              string `json:"id"`
+	Source          string `json:"source"`
+	Type            string `json:"type"`
+	Time            string `json:"time,omitempty"`
+	DataContentType string `json:"datacontenttype"`
+	Data            Event  `json:"data"`
+}
+
+// newCloudEvent wraps event in a CloudEvents envelope, using event.EventType as the CloudEvents
+// "type" (falling back to a generic type if unset) and source as the "source" attribute.
+func newCloudEvent(event Event, source string) cloudEvent {
+	eventType := event.EventType
+	if eventType == "" {
+		eventType = "com.sap.ans.event"
+	}
+
+	var eventTime string
+	if event.EventTimestamp != 0 {
+		eventTime = time.Unix(event.EventTimestamp, 0).UTC().Format(time.RFC3339)
+	}
+
+	return cloudEvent{
+		SpecVersion:     cloudEventsSpecVersion,
+		ID:              newCloudEventID(),
+		Source:          source,
+		Type:            eventType,
+		Time:            eventTime,
+		DataContentType: "application/json",
+		Data:            event,
+	}
+}
+
+// newCloudEventID returns a random, URN-safe identifier unique enough for the CloudEvents "id"
+// attribute, which only needs to be unique in combination with "source".
+func newCloudEventID() string {
+	var raw [16]byte
+	if _, err := rand.Read(raw[:]); err != nil {
+		return fmt.Sprintf("%d", time.Now().UnixNano())
+	}
+	return fmt.Sprintf("%x", raw)
+}
+
+// cloudEventSource returns ans.CloudEventSource, falling back to ans.URL when unset.
+func (ans ANS) cloudEventSource() string {
+	if ans.CloudEventSource != "" {
+		return ans.CloudEventSource
+	}
+	return ans.URL
+}
+
+// SendCloudEvent sends event to the SAP Alert Notification Service as a CloudEvents 1.0
+// structured JSON envelope, regardless of ans.Format. It is equivalent to calling
+// SendCloudEventContext with context.Background().
+func (ans ANS) SendCloudEvent(event Event) error {
+	return ans.SendCloudEventContext(context.Background(), event)
+}
+
+// SendCloudEventContext is like SendCloudEvent but honors ctx for cancelling retries, the same
+// way SendContext does for the plain ANS format.
+func (ans ANS) SendCloudEventContext(ctx context.Context, event Event) error {
+	ans.Format = FormatCloudEvents
+	return ans.SendContext(ctx, event)
+}
@@ -0,0 +1,60 @@
+package ans
+
+import (
+	"testing"
+	"time"
+
+	"github.com/SAP/jenkins-library/pkg/xsuaa"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewCloudEventSetsEnvelopeAttributes(t *testing.T) {
+	timestamp := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+	event := Event{
+		EventType:      "my.event",
+		EventTimestamp: timestamp.Unix(),
+		Severity:       infoSeverity,
+	}
+
+	ce := newCloudEvent(event, "https://example.com/ans")
+
+	assert.Equal(t, cloudEventsSpecVersion, ce.SpecVersion)
+	assert.Equal(t, "https://example.com/ans", ce.Source)
+	assert.Equal(t, "my.event", ce.Type)
+	assert.Equal(t, "application/json", ce.DataContentType)
+	assert.Equal(t, timestamp.Format(time.RFC3339), ce.Time)
+	assert.Equal(t, event, ce.Data)
+	assert.NotEqual(t, "", ce.ID)
+}
+
+func TestNewCloudEventFallsBackToGenericType(t *testing.T) {
+	ce := newCloudEvent(Event{}, "https://example.com/ans")
+	assert.Equal(t, "com.sap.ans.event", ce.Type)
+}
+
+func TestNewCloudEventOmitsTimeWhenEventTimestampIsUnset(t *testing.T) {
+	ce := newCloudEvent(Event{}, "https://example.com/ans")
+	assert.Equal(t, "", ce.Time)
+}
+
+func TestNewCloudEventIDsAreUnique(t *testing.T) {
+	seen := map[string]bool{}
+	for i := 0; i < 100; i++ {
+		id := newCloudEventID()
+		assert.False(t, seen[id], "expected newCloudEventID to generate a unique id")
+		seen[id] = true
+	}
+}
+
+func TestANSCloudEventSourceFallsBackToURL(t *testing.T) {
+	ans := ANS{URL: "https://ans.example.com"}
+	assert.Equal(t, "https://ans.example.com", ans.cloudEventSource())
+
+	ans.CloudEventSource = "https://events.example.com"
+	assert.Equal(t, "https://events.example.com", ans.cloudEventSource())
+}
+
+func TestNewANSWiresCloudEventSourceFromConfiguration(t *testing.T) {
+	ans := NewANS(xsuaa.XSUAA{}, "https://ans.example.com", Configuration{CloudEventSource: "https://events.example.com"})
+	assert.Equal(t, "https://events.example.com", ans.CloudEventSource)
+}
@@ -0,0 +1,80 @@
+package ans
+
+import (
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ANSHook is a logrus.Hook that forwards log entries to the SAP Alert Notification Service (or
+// any other Client). Each entry is converted into an Event: Severity/Category come from
+// TranslateLogrusLogLevel, Subject is built from SubjectFormat, Tags come from entry.Data and
+// Body from entry.Message. Set EventTemplate to an ANS Event JSON string (typically
+// Configuration.EventTemplate) to preload defaults, e.g. a Resource, that every entry should
+// carry; per-entry fields always take precedence over the template.
+type ANSHook struct {
+	Client        Client
+	LogLevels     []logrus.Level
+	SubjectFormat string
+	EventTemplate string
+
+	// Async, when set, makes Fire enqueue onto this BufferedClient instead of calling
+	// Client.Send directly, so logging never blocks on network latency to the backend.
+	Async *BufferedClient
+}
+
+// NewANSHook creates an ANSHook that fires for all logrus levels and sends via client. Use the
+// struct fields directly afterwards to narrow LogLevels, set a SubjectFormat or enable Async mode.
+func NewANSHook(client Client, config Configuration) *ANSHook {
+	return &ANSHook{
+		Client:        client,
+		LogLevels:     logrus.AllLevels,
+		EventTemplate: config.EventTemplate,
+	}
+}
+
+// Levels returns the logrus levels this hook fires for, satisfying logrus.Hook.
+func (hook *ANSHook) Levels() []logrus.Level {
+	if hook.LogLevels == nil {
+		return logrus.AllLevels
+	}
+	return hook.LogLevels
+}
+
+// Fire converts entry into an Event, overlaying it onto EventTemplate if one is set, and
+// dispatches it via Async if configured, otherwise via Client.Send.
+func (hook *ANSHook) Fire(entry *logrus.Entry) error {
+	var event Event
+	if hook.EventTemplate != "" {
+		if err := event.MergeWithJSON([]byte(hook.EventTemplate)); err != nil {
+			return err
+		}
+	}
+
+	event.Severity, event.Category = TranslateLogrusLogLevel(entry.Level)
+	event.EventTimestamp = entry.Time.Unix()
+	event.Body = entry.Message
+	event.Subject = hook.subject(entry)
+
+	if len(entry.Data) > 0 {
+		if event.Tags == nil {
+			event.Tags = make(map[string]interface{}, len(entry.Data))
+		}
+		for key, value := range entry.Data {
+			event.Tags[key] = value
+		}
+	}
+
+	if hook.Async != nil {
+		return hook.Async.Enqueue(event)
+	}
+	return hook.Client.Send(event)
+}
+
+func (hook *ANSHook) subject(entry *logrus.Entry) string {
+	format := hook.SubjectFormat
+	if format == "" {
+		format = "%s"
+	}
+	return fmt.Sprintf(format, entry.Message)
+}
@@ -0,0 +1,99 @@
+package ans
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestANSHookFireTranslatesSeverityAndSubject(t *testing.T) {
+	client := &fakeClient{}
+	hook := NewANSHook(client, Configuration{})
+	hook.SubjectFormat = "log: %s"
+
+	now := time.Now()
+	err := hook.Fire(&logrus.Entry{Level: logrus.ErrorLevel, Message: "something broke", Time: now})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, client.count())
+	event := client.sent[0]
+	assert.Equal(t, "log: something broke", event.Subject)
+	assert.Equal(t, "something broke", event.Body)
+	assert.Equal(t, now.Unix(), event.EventTimestamp)
+
+	wantSeverity, wantCategory := TranslateLogrusLogLevel(logrus.ErrorLevel)
+	assert.Equal(t, wantSeverity, event.Severity)
+	assert.Equal(t, wantCategory, event.Category)
+}
+
+func TestANSHookFireMergesEntryDataOntoTemplateTags(t *testing.T) {
+	client := &fakeClient{}
+	hook := NewANSHook(client, Configuration{EventTemplate: `{"tags": {"component": "pipeline"}}`})
+
+	err := hook.Fire(&logrus.Entry{
+		Level:   logrus.InfoLevel,
+		Message: "step started",
+		Data:    logrus.Fields{"stepName": "build"},
+	})
+
+	assert.NoError(t, err)
+	event := client.sent[0]
+	assert.Equal(t, "pipeline", event.Tags["component"], "template tags should survive entry data merging in")
+	assert.Equal(t, "build", event.Tags["stepName"], "entry.Data should be merged onto the template's tags")
+}
+
+func TestANSHookFireEntryDataOverridesTemplateTagOfSameKey(t *testing.T) {
+	client := &fakeClient{}
+	hook := NewANSHook(client, Configuration{EventTemplate: `{"tags": {"stepName": "default"}}`})
+
+	err := hook.Fire(&logrus.Entry{
+		Level: logrus.InfoLevel,
+		Data:  logrus.Fields{"stepName": "build"},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "build", client.sent[0].Tags["stepName"])
+}
+
+func TestANSHookFireWithoutEntryDataLeavesTemplateTagsUntouched(t *testing.T) {
+	client := &fakeClient{}
+	hook := NewANSHook(client, Configuration{EventTemplate: `{"tags": {"component": "pipeline"}}`})
+
+	err := hook.Fire(&logrus.Entry{Level: logrus.InfoLevel})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "pipeline", client.sent[0].Tags["component"])
+}
+
+func TestANSHookLevelsDefaultsToAllLevels(t *testing.T) {
+	hook := NewANSHook(&fakeClient{}, Configuration{})
+	assert.Equal(t, logrus.AllLevels, hook.Levels())
+}
+
+func TestANSHookLevelsReturnsNarrowedLevels(t *testing.T) {
+	hook := NewANSHook(&fakeClient{}, Configuration{})
+	hook.LogLevels = []logrus.Level{logrus.ErrorLevel, logrus.FatalLevel}
+
+	assert.Equal(t, []logrus.Level{logrus.ErrorLevel, logrus.FatalLevel}, hook.Levels())
+}
+
+func TestANSHookFireDispatchesAsynchronouslyWhenAsyncIsSet(t *testing.T) {
+	client := &fakeClient{}
+	bc := NewBufferedClient(client, BufferedClientConfig{FlushInterval: time.Hour, MaxBatchSize: 100})
+	defer bc.Close()
+
+	hook := NewANSHook(client, Configuration{})
+	hook.Async = bc
+
+	err := hook.Fire(&logrus.Entry{Level: logrus.InfoLevel, Message: "async event"})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, client.count(), "Fire should enqueue, not send synchronously, when Async is set")
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.NoError(t, bc.Flush(ctx))
+	assert.Equal(t, 1, client.count(), "the flush should have delivered the enqueued event")
+}
@@ -0,0 +1,344 @@
+package ans
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/SAP/jenkins-library/pkg/body"
+	"github.com/nats-io/nats.go"
+	"github.com/pkg/errors"
+)
+
+// Transport selectors for Configuration.Transport. TransportANS keeps the existing SAP Alert
+// Notification Service behavior; the others route events to a different sink entirely.
+const (
+	TransportANS      = "ans"
+	TransportNATS     = "nats"
+	TransportMSTeams  = "msteams"
+	TransportOpsgenie = "opsgenie"
+	TransportWebhook  = "webhook"
+)
+
+// Transport abstracts the delivery mechanism for an Event so that pipeline steps can route
+// notifications to destinations other than the SAP Alert Notification Service. ANS itself
+// satisfies this interface via its existing Send method.
+type Transport interface {
+	Send(event Event) error
+}
+
+// NATSConfig configures the NATS JetStream publisher transport.
+type NATSConfig struct {
+	URL     string `json:"url,omitempty"`
+	Subject string `json:"subject,omitempty"`
+	Stream  string `json:"stream,omitempty"`
+}
+
+// MSTeamsConfig configures the Microsoft Teams incoming webhook transport.
+type MSTeamsConfig struct {
+	WebhookURL string `json:"webhookUrl,omitempty"`
+}
+
+// OpsgenieConfig configures the Opsgenie REST v2 alerts transport.
+type OpsgenieConfig struct {
+	APIURL string `json:"apiUrl,omitempty"`
+	APIKey string `json:"apiKey,omitempty"`
+	Source string `json:"source,omitempty"`
+}
+
+// WebhookConfig configures the generic JSON webhook transport.
+type WebhookConfig struct {
+	URL     string            `json:"url,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// postJSON POSTs payload as JSON to url, adding any extra headers, and treats any non-2xx
+// response as an error, reading the response body for diagnostics the same way ANS.Send does.
+func postJSON(url string, payload interface{}, headers map[string]string) error {
+	requestBody, err := json.Marshal(payload)
+	if err != nil {
+		return errors.Wrap(err, "error marshalling event payload")
+	}
+
+	request, err := http.NewRequest(http.MethodPost, url, bytes.NewBuffer(requestBody))
+	if err != nil {
+		return err
+	}
+	request.Header.Add("Content-Type", "application/json")
+	for key, value := range headers {
+		request.Header.Add(key, value)
+	}
+
+	httpClient := http.Client{}
+	response, err := httpClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		statusCodeError := fmt.Errorf("http request to '%s' failed with status code %d", url, response.StatusCode)
+		responseBody, err := body.ReadResponseBody(response)
+		if err != nil {
+			return errors.Wrapf(err, "%s; reading response body failed", statusCodeError.Error())
+		}
+		return fmt.Errorf("%s; response body: %s", statusCodeError.Error(), responseBody)
+	}
+
+	return nil
+}
+
+// NATSTransport publishes events to a NATS JetStream subject for async fan-out to durable
+// consumers. The JetStream connection is dialed lazily on the first Send and reused afterwards;
+// it is a pointer receiver because of that shared, lazily-initialized connection state.
+type NATSTransport struct {
+	Config NATSConfig
+
+	mu      sync.Mutex
+	conn    jetStreamPublisher
+	connect func(url string) (jetStreamPublisher, error)
+}
+
+// NewNATSTransport returns a NATSTransport that publishes to a real NATS JetStream subject.
+func NewNATSTransport(config NATSConfig) *NATSTransport {
+	return &NATSTransport{Config: config, connect: connectJetStream}
+}
+
+// jetStreamPublisher is the subset of the NATS JetStream client used by NATSTransport, kept as
+// an interface so tests can substitute a fake instead of dialing a real NATS server.
+type jetStreamPublisher interface {
+	Publish(subject string, data []byte) error
+	Close()
+}
+
+// natsJetStreamConn adapts a real *nats.Conn/JetStreamContext pair to jetStreamPublisher.
+type natsJetStreamConn struct {
+	nc *nats.Conn
+	js nats.JetStreamContext
+}
+
+func (c *natsJetStreamConn) Publish(subject string, data []byte) error {
+	_, err := c.js.Publish(subject, data)
+	return err
+}
+
+func (c *natsJetStreamConn) Close() { c.nc.Close() }
+
+// connectJetStream dials url and opens a JetStream context on it.
+func connectJetStream(url string) (jetStreamPublisher, error) {
+	nc, err := nats.Connect(url)
+	if err != nil {
+		return nil, err
+	}
+	js, err := nc.JetStream()
+	if err != nil {
+		nc.Close()
+		return nil, err
+	}
+	return &natsJetStreamConn{nc: nc, js: js}, nil
+}
+
+// ensureConn returns the shared JetStream connection, dialing it on first use.
+func (t *NATSTransport) ensureConn() (jetStreamPublisher, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.conn != nil {
+		return t.conn, nil
+	}
+	connect := t.connect
+	if connect == nil {
+		connect = connectJetStream
+	}
+	conn, err := connect(t.Config.URL)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error connecting to NATS at '%s'", t.Config.URL)
+	}
+	t.conn = conn
+	return conn, nil
+}
+
+// Send marshals the event and publishes it to the configured JetStream subject.
+func (t *NATSTransport) Send(event Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return errors.Wrap(err, "error marshalling event for NATS transport")
+	}
+
+	conn, err := t.ensureConn()
+	if err != nil {
+		return err
+	}
+
+	if err := conn.Publish(t.Config.Subject, data); err != nil {
+		return errors.Wrapf(err, "error publishing event to NATS subject '%s'", t.Config.Subject)
+	}
+	return nil
+}
+
+// teamsMessageCard is the subset of the MS Teams MessageCard schema needed to render an Event.
+type teamsMessageCard struct {
+	Type       string `json:"@type"`
+	Context    string `json:"@context"`
+	ThemeColor string `json:"themeColor"`
+	Summary    string `json:"summary"`
+	Title      string `json:"title"`
+	Text       string `json:"text"`
+}
+
+// MSTeamsTransport delivers events to a Microsoft Teams channel via an incoming webhook.
+type MSTeamsTransport struct {
+	Config MSTeamsConfig
+}
+
+// Send converts the event into a Teams MessageCard and posts it to the configured webhook URL.
+func (t MSTeamsTransport) Send(event Event) error {
+	card := teamsMessageCard{
+		Type:       "MessageCard",
+		Context:    "http://schema.org/extensions",
+		ThemeColor: teamsThemeColor(event.Severity),
+		Summary:    event.Subject,
+		Title:      event.Subject,
+		Text:       event.Body,
+	}
+	return postJSON(t.Config.WebhookURL, card, nil)
+}
+
+func teamsThemeColor(severity string) string {
+	switch severity {
+	case errorSeverity, fatalSeverity:
+		return "FF0000"
+	case warningSeverity:
+		return "FFA500"
+	default:
+		return "0076D7"
+	}
+}
+
+// opsgenieAlert is the subset of the Opsgenie "create alert" v2 request body populated from Event.
+type opsgenieAlert struct {
+	Message  string                 `json:"message"`
+	Alias    string                 `json:"alias,omitempty"`
+	Source   string                 `json:"source,omitempty"`
+	Priority string                 `json:"priority,omitempty"`
+	Entity   string                 `json:"entity,omitempty"`
+	Tags     []string               `json:"tags,omitempty"`
+	Details  map[string]interface{} `json:"details,omitempty"`
+}
+
+// OpsgenieTransport creates Opsgenie alerts from events via the REST v2 alerts API.
+type OpsgenieTransport struct {
+	Config OpsgenieConfig
+}
+
+// Send maps the event's subject/severity/resource/tags onto Opsgenie's alert fields and creates it.
+func (t OpsgenieTransport) Send(event Event) error {
+	alert := opsgenieAlert{
+		Message:  event.Subject,
+		Source:   t.Config.Source,
+		Priority: opsgeniePriority(event.Severity),
+		Details:  event.Tags,
+	}
+	if event.Resource != nil {
+		alert.Entity = event.Resource.ResourceName
+		alert.Alias = fmt.Sprintf("%s/%s", event.Resource.ResourceType, event.Resource.ResourceName)
+	} else {
+		alert.Alias = event.EventType
+	}
+	for tag := range event.Tags {
+		alert.Tags = append(alert.Tags, tag)
+	}
+
+	url := strings.TrimSuffix(t.Config.APIURL, "/") + "/v2/alerts"
+	headers := map[string]string{authHeaderKey: "GenieKey " + t.Config.APIKey}
+	return postJSON(url, alert, headers)
+}
+
+func opsgeniePriority(severity string) string {
+	switch severity {
+	case fatalSeverity:
+		return "P1"
+	case errorSeverity:
+		return "P2"
+	case warningSeverity:
+		return "P3"
+	default:
+		return "P5"
+	}
+}
+
+// WebhookTransport delivers the raw Event as JSON to an arbitrary HTTP endpoint.
+type WebhookTransport struct {
+	Config WebhookConfig
+}
+
+// Send posts the event unchanged to the configured webhook URL.
+func (t WebhookTransport) Send(event Event) error {
+	return postJSON(t.Config.URL, event, t.Config.Headers)
+}
+
+// MultiTransport fans an event out to several Transports in parallel and reports every failure,
+// so pipeline steps can send to more than one sink without changing how they call Send.
+type MultiTransport struct {
+	Transports []Transport
+}
+
+// Send dispatches the event to all configured transports concurrently and returns a combined
+// error if one or more of them failed; the event is still sent to every transport regardless.
+func (m MultiTransport) Send(event Event) error {
+	var wg sync.WaitGroup
+	errs := make([]error, len(m.Transports))
+
+	for i, transport := range m.Transports {
+		wg.Add(1)
+		go func(i int, transport Transport) {
+			defer wg.Done()
+			if err := transport.Send(event); err != nil {
+				errs[i] = errors.Wrapf(err, "transport %d failed", i)
+			}
+		}(i, transport)
+	}
+	wg.Wait()
+
+	var combined []string
+	for _, err := range errs {
+		if err != nil {
+			combined = append(combined, err.Error())
+		}
+	}
+	if len(combined) > 0 {
+		return fmt.Errorf("one or more transports failed: %s", strings.Join(combined, "; "))
+	}
+	return nil
+}
+
+// NewTransports builds the list of Transports selected by Configuration.Transport. Transport may
+// name a single transport (e.g. "opsgenie") or a comma-separated list to send to several sinks in
+// parallel (e.g. "ans,opsgenie,msteams"); an empty value defaults to the ANS transport.
+func NewTransports(config Configuration, ansClient Transport) ([]Transport, error) {
+	selector := config.Transport
+	if selector == "" {
+		selector = TransportANS
+	}
+
+	var transports []Transport
+	for _, name := range strings.Split(selector, ",") {
+		switch strings.TrimSpace(name) {
+		case TransportANS:
+			transports = append(transports, ansClient)
+		case TransportNATS:
+			transports = append(transports, NewNATSTransport(config.NATS))
+		case TransportMSTeams:
+			transports = append(transports, MSTeamsTransport{Config: config.MSTeams})
+		case TransportOpsgenie:
+			transports = append(transports, OpsgenieTransport{Config: config.Opsgenie})
+		case TransportWebhook:
+			transports = append(transports, WebhookTransport{Config: config.Webhook})
+		default:
+			return nil, fmt.Errorf("unknown notification transport '%s'", name)
+		}
+	}
+	return transports, nil
+}
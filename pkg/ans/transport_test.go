@@ -0,0 +1,67 @@
+package ans
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeJetStreamPublisher struct {
+	published [][2]string
+	err       error
+	closed    bool
+}
+
+func (f *fakeJetStreamPublisher) Publish(subject string, data []byte) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.published = append(f.published, [2]string{subject, string(data)})
+	return nil
+}
+
+func (f *fakeJetStreamPublisher) Close() { f.closed = true }
+
+func TestNATSTransportSendPublishesOnceAndReusesConnection(t *testing.T) {
+	fake := &fakeJetStreamPublisher{}
+	dials := 0
+	transport := &NATSTransport{
+		Config: NATSConfig{URL: "nats://localhost:4222", Subject: "ans.events"},
+		connect: func(url string) (jetStreamPublisher, error) {
+			dials++
+			return fake, nil
+		},
+	}
+
+	assert.NoError(t, transport.Send(Event{EventType: "first"}))
+	assert.NoError(t, transport.Send(Event{EventType: "second"}))
+
+	assert.Equal(t, 1, dials, "expected the JetStream connection to be dialed only once")
+	assert.Len(t, fake.published, 2)
+	assert.Equal(t, "ans.events", fake.published[0][0])
+	assert.False(t, fake.closed)
+}
+
+func TestNATSTransportSendWrapsPublishError(t *testing.T) {
+	fake := &fakeJetStreamPublisher{err: errors.New("subject not found")}
+	transport := &NATSTransport{
+		Config:  NATSConfig{URL: "nats://localhost:4222", Subject: "ans.events"},
+		connect: func(url string) (jetStreamPublisher, error) { return fake, nil },
+	}
+
+	err := transport.Send(Event{EventType: "first"})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "ans.events")
+}
+
+func TestNATSTransportSendWrapsConnectError(t *testing.T) {
+	transport := &NATSTransport{
+		Config:  NATSConfig{URL: "nats://localhost:4222", Subject: "ans.events"},
+		connect: func(url string) (jetStreamPublisher, error) { return nil, errors.New("connection refused") },
+	}
+
+	err := transport.Send(Event{EventType: "first"})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "nats://localhost:4222")
+}